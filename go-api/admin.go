@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// purgeScanCount is the COUNT hint passed to SCAN; it bounds how much
+	// work one round trip does, not how many keys are returned.
+	purgeScanCount = 100
+
+	// idempotencyKeyTTL must match the TTL ServeHTTP sets on the lock and
+	// status keys, so age can be derived from the remaining TTL.
+	idempotencyKeyTTL = 24 * time.Hour
+
+	// lapsedThreshold is how long a key may sit in "pending" or
+	// "processing" before scope=lapsed considers it stuck.
+	lapsedThreshold = time.Hour
+)
+
+// purgeableStatuses are the in-flight states a publisher crash can leave a
+// request wedged in.
+var purgeableStatuses = map[string]bool{"pending": true, "processing": true}
+
+// purgeStuckScript deletes the status and lock keys for a request in one
+// round trip, but only if the status key still holds one of the expected
+// values — guarding against a race where the request completes between the
+// purge handler's scan and the delete. An empty expected value (scope=all)
+// skips the check and deletes unconditionally.
+var purgeStuckScript = redis.NewScript(`
+local status = redis.call("GET", KEYS[1])
+if status == false then
+	return 0
+end
+if ARGV[1] ~= "" and status ~= ARGV[1] and status ~= ARGV[2] then
+	return 0
+end
+redis.call("DEL", KEYS[1], KEYS[2])
+return 1
+`)
+
+// HandlePurgeIdempotency scans status:* keys and, for scope=lapsed, purges
+// the status and lock key pair for any request stuck in "pending" or
+// "processing" for longer than lapsedThreshold — the operational case where
+// a publisher crash between Set("processing") and Publish leaves a key
+// wedged until its 24h TTL expires. scope=all purges every idempotency key
+// regardless of status or age and must be enabled via allowFullPurge.
+func (h *TransferHandler) HandlePurgeIdempotency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.isAdminAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	scope := r.URL.Query().Get("scope")
+	var expectA, expectB string
+	switch scope {
+	case "lapsed":
+		expectA, expectB = "pending", "processing"
+	case "all":
+		if !h.allowFullPurge {
+			http.Error(w, "scope=all is disabled", http.StatusForbidden)
+			return
+		}
+	default:
+		http.Error(w, "Unknown scope, expected lapsed or all", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	var scanned, purged, skipped int
+	var cursor uint64
+	for {
+		statusKeys, next, err := h.rdb.Scan(ctx, cursor, "status:*", purgeScanCount).Result()
+		if err != nil {
+			http.Error(w, "Redis Error", http.StatusInternalServerError)
+			return
+		}
+
+		for _, statusKey := range statusKeys {
+			scanned++
+
+			lapsed := true
+			if scope == "lapsed" {
+				lapsed, err = h.isLapsed(ctx, statusKey)
+				if err != nil {
+					skipped++
+					continue
+				}
+			}
+			if !lapsed {
+				skipped++
+				continue
+			}
+
+			lockKey := strings.TrimPrefix(statusKey, "status:")
+			res, err := purgeStuckScript.Run(ctx, h.rdb, []string{statusKey, lockKey}, expectA, expectB).Int()
+			if err != nil || res == 0 {
+				skipped++
+				continue
+			}
+			purged++
+		}
+
+		cursor = next
+		log.Printf("idempotency purge: scope=%s scanned=%d purged=%d skipped=%d", scope, scanned, purged, skipped)
+		if cursor == 0 {
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{
+		"scanned": scanned,
+		"purged":  purged,
+		"skipped": skipped,
+	})
+}
+
+// isLapsed reports whether statusKey holds a purgeable status and has sat
+// there longer than lapsedThreshold, derived from its remaining TTL.
+func (h *TransferHandler) isLapsed(ctx context.Context, statusKey string) (bool, error) {
+	val, err := h.rdb.Get(ctx, statusKey).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !purgeableStatuses[val] {
+		return false, nil
+	}
+
+	ttl, err := h.rdb.TTL(ctx, statusKey).Result()
+	if err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		// No expiry (or the key vanished): we can't derive an age, so
+		// don't treat it as lapsed.
+		return false, nil
+	}
+
+	age := idempotencyKeyTTL - ttl
+	return age >= lapsedThreshold, nil
+}
+
+func (h *TransferHandler) isAdminAuthorized(r *http.Request) bool {
+	if h.adminToken == "" {
+		return false
+	}
+	got := r.Header.Get("X-Admin-Token")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.adminToken)) == 1
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPurgeHandler(t *testing.T) (*TransferHandler, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &TransferHandler{rdb: rdb, adminToken: "s3cret"}, mr
+}
+
+func purgeRequest(scope string, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/admin/idempotency/purge?scope="+scope, nil)
+	if token != "" {
+		req.Header.Set("X-Admin-Token", token)
+	}
+	return req
+}
+
+func TestHandlePurgeIdempotency_Unauthorized(t *testing.T) {
+	handler, _ := newPurgeHandler(t)
+	w := httptest.NewRecorder()
+
+	handler.HandlePurgeIdempotency(w, purgeRequest("lapsed", "wrong-token"))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHandlePurgeIdempotency_UnknownScope(t *testing.T) {
+	handler, _ := newPurgeHandler(t)
+	w := httptest.NewRecorder()
+
+	handler.HandlePurgeIdempotency(w, purgeRequest("bogus", "s3cret"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlePurgeIdempotency_LapsedPurgesOnlyStuckKeys(t *testing.T) {
+	handler, mr := newPurgeHandler(t)
+
+	// A stuck request, pending well past lapsedThreshold.
+	mr.Set("status:stuck", "pending")
+	mr.SetTTL("status:stuck", idempotencyKeyTTL-2*lapsedThreshold)
+	mr.Set("stuck", "processing")
+	mr.SetTTL("stuck", idempotencyKeyTTL-2*lapsedThreshold)
+
+	// A fresh request, still well within the threshold.
+	mr.Set("status:fresh", "pending")
+	mr.SetTTL("status:fresh", idempotencyKeyTTL)
+	mr.Set("fresh", "processing")
+	mr.SetTTL("fresh", idempotencyKeyTTL)
+
+	// A completed request: not purgeable even though it's old.
+	mr.Set("status:done", "completed")
+	mr.SetTTL("status:done", idempotencyKeyTTL-2*lapsedThreshold)
+
+	w := httptest.NewRecorder()
+	handler.HandlePurgeIdempotency(w, purgeRequest("lapsed", "s3cret"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var summary map[string]int
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 3, summary["scanned"])
+	assert.Equal(t, 1, summary["purged"])
+	assert.Equal(t, 2, summary["skipped"])
+
+	assert.False(t, mr.Exists("status:stuck"))
+	assert.False(t, mr.Exists("stuck"))
+	assert.True(t, mr.Exists("status:fresh"))
+	assert.True(t, mr.Exists("status:done"))
+}
+
+func TestHandlePurgeIdempotency_AllRequiresFlag(t *testing.T) {
+	handler, mr := newPurgeHandler(t)
+	mr.Set("status:anything", "completed")
+
+	w := httptest.NewRecorder()
+	handler.HandlePurgeIdempotency(w, purgeRequest("all", "s3cret"))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestHandlePurgeIdempotency_AllWipesEverything(t *testing.T) {
+	handler, mr := newPurgeHandler(t)
+	handler.allowFullPurge = true
+
+	mr.Set("status:anything", "completed")
+	mr.Set("anything", "processing")
+
+	w := httptest.NewRecorder()
+	handler.HandlePurgeIdempotency(w, purgeRequest("all", "s3cret"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var summary map[string]int
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 1, summary["purged"])
+
+	assert.False(t, mr.Exists("status:anything"))
+	assert.False(t, mr.Exists("anything"))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-amqp/v2/pkg/amqp"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+// BrokerKind selects which message broker backend Publish/Subscribe runs
+// against.
+type BrokerKind string
+
+const (
+	// BrokerAMQP publishes to RabbitMQ via watermill-amqp.
+	BrokerAMQP BrokerKind = "amqp"
+	// BrokerRedisStream publishes to a Redis stream via watermill-redisstream,
+	// using consumer groups on the subscribe side. It lets small deployments
+	// run purely against the Redis instance already in use and drop
+	// RabbitMQ entirely.
+	BrokerRedisStream BrokerKind = "redisstream"
+)
+
+// BrokerConfig selects and configures the broker backend. Kind is the one
+// knob operators need to flip between RabbitMQ and Redis Streams; the rest
+// are only consulted by the backend Kind selects.
+type BrokerConfig struct {
+	Kind BrokerKind
+
+	AMQPURI string
+
+	// RedisClient is the Redis connection BrokerRedisStream publishes and
+	// subscribes through. It should be the same redis.UniversalClient the
+	// rest of the process uses (built via NewRedisClient), so the broker
+	// picks up whatever topology — single-node, Sentinel, or Cluster — and
+	// auth/TLS settings that client was configured with instead of opening
+	// a second, unauthenticated connection of its own. Ignored by BrokerAMQP.
+	RedisClient redis.UniversalClient
+	// RedisConsumerGroup is the consumer group used when reading topics back
+	// off a Redis stream. Ignored by BrokerAMQP.
+	RedisConsumerGroup string
+	// RedisMaxLen bounds each stream with XADD MAXLEN ~ on publish; zero
+	// leaves the stream unbounded. Ignored by BrokerAMQP.
+	RedisMaxLen int64
+	// RedisClaimIdleTime is how long a pending message must sit unacked
+	// before XAUTOCLAIM hands it to another consumer. Zero falls back to
+	// redisstream.DefaultMaxIdleTime (60s). Ignored by BrokerAMQP.
+	RedisClaimIdleTime time.Duration
+
+	// AMQPConfirmDelivery makes Publish block for a broker confirm before
+	// returning. The OutboxRelay sets this so it only trims an outbox entry
+	// once the publish is actually confirmed. Ignored by BrokerRedisStream,
+	// whose Publish already waits for the Redis response.
+	AMQPConfirmDelivery bool
+}
+
+// NewPublisher builds the publish side of the broker cfg selects.
+func NewPublisher(cfg BrokerConfig, logger watermill.LoggerAdapter) (message.Publisher, error) {
+	switch cfg.Kind {
+	case BrokerRedisStream:
+		return redisstream.NewPublisher(redisstream.PublisherConfig{
+			Client:        cfg.RedisClient,
+			DefaultMaxlen: cfg.RedisMaxLen,
+		}, logger)
+	case BrokerAMQP, "":
+		amqpConfig := amqp.NewDurableQueueConfig(cfg.AMQPURI)
+		amqpConfig.Publish.ConfirmDelivery = cfg.AMQPConfirmDelivery
+		return amqp.NewPublisher(amqpConfig, logger)
+	default:
+		return nil, fmt.Errorf("broker: unknown kind %q", cfg.Kind)
+	}
+}
+
+// NewSubscriber builds the consume side of the broker cfg selects, for a
+// worker process draining topicName. On BrokerRedisStream this joins
+// RedisConsumerGroup and periodically claims messages idle past
+// RedisClaimIdleTime to recover from crashed consumers.
+func NewSubscriber(cfg BrokerConfig, logger watermill.LoggerAdapter) (message.Subscriber, error) {
+	switch cfg.Kind {
+	case BrokerRedisStream:
+		return redisstream.NewSubscriber(redisstream.SubscriberConfig{
+			Client:        cfg.RedisClient,
+			ConsumerGroup: cfg.RedisConsumerGroup,
+			MaxIdleTime:   cfg.RedisClaimIdleTime,
+		}, logger)
+	case BrokerAMQP, "":
+		return amqp.NewSubscriber(amqp.NewDurableQueueConfig(cfg.AMQPURI), logger)
+	default:
+		return nil, fmt.Errorf("broker: unknown kind %q", cfg.Kind)
+	}
+}
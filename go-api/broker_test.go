@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPublisher_UnknownKind(t *testing.T) {
+	_, err := NewPublisher(BrokerConfig{Kind: "carrier-pigeon"}, watermill.NewStdLogger(false, false))
+	assert.Error(t, err)
+}
+
+func TestNewSubscriber_UnknownKind(t *testing.T) {
+	_, err := NewSubscriber(BrokerConfig{Kind: "carrier-pigeon"}, watermill.NewStdLogger(false, false))
+	assert.Error(t, err)
+}
+
+// TestNewPublisher_RedisStreamUsesGivenClient guards against re-deriving a
+// fresh, unauthenticated client from a raw address instead of reusing the
+// RedisClient the caller already configured (and thus its Sentinel/Cluster
+// topology, auth and TLS settings).
+func TestNewPublisher_RedisStreamUsesGivenClient(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	_, err = NewPublisher(BrokerConfig{
+		Kind:        BrokerRedisStream,
+		RedisClient: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+	}, watermill.NewStdLogger(false, false))
+	assert.NoError(t, err)
+
+	_, err = NewPublisher(BrokerConfig{Kind: BrokerRedisStream}, watermill.NewStdLogger(false, false))
+	assert.Error(t, err)
+}
+
+func TestNewSubscriber_RedisStreamUsesGivenClient(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	_, err = NewSubscriber(BrokerConfig{
+		Kind:        BrokerRedisStream,
+		RedisClient: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+	}, watermill.NewStdLogger(false, false))
+	assert.NoError(t, err)
+
+	_, err = NewSubscriber(BrokerConfig{Kind: BrokerRedisStream}, watermill.NewStdLogger(false, false))
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// watcherDisconnected is sent to every active subscriber when the keyspace
+// notification subscription drops, since no real status value is ever empty.
+// Callers should treat it as a signal to fall back to polling rather than as
+// a status update.
+const watcherDisconnected = ""
+
+const (
+	watcherMinBackoff = time.Second
+	watcherMaxBackoff = 30 * time.Second
+)
+
+// KeyWatcher subscribes to Redis keyspace notifications for "status:*" keys
+// and fans value changes out to whoever is waiting on a particular key via
+// Watch. A single KeyWatcher is shared by the whole process: one PSUBSCRIBE
+// connection backs any number of long-polling HandleStatus callers.
+type KeyWatcher struct {
+	rc redis.UniversalClient
+	db int
+
+	mu          sync.Mutex
+	subscribers map[string][]chan string
+}
+
+// NewKeyWatcher creates a watcher for keyspace events on db. It does not
+// start watching until Run is called.
+func NewKeyWatcher(rc redis.UniversalClient, db int) *KeyWatcher {
+	return &KeyWatcher{
+		rc:          rc,
+		db:          db,
+		subscribers: make(map[string][]chan string),
+	}
+}
+
+// Bootstrap issues CONFIG SET notify-keyspace-events so keyspace events fire
+// out of the box against a default Redis. events is the flag string Redis
+// expects (e.g. "KEA"); pass "" to skip and rely on the server already being
+// configured.
+func (w *KeyWatcher) Bootstrap(ctx context.Context, events string) error {
+	if events == "" {
+		return nil
+	}
+	return w.rc.ConfigSet(ctx, "notify-keyspace-events", events).Err()
+}
+
+// Watch registers interest in key and returns a channel that receives the
+// key's new value on every change, plus a deregister func that the caller
+// must invoke when it stops waiting. Register before reading the key's
+// current value, otherwise a change landing between the read and the
+// subscribe is missed.
+func (w *KeyWatcher) Watch(key string) (<-chan string, func()) {
+	ch := make(chan string, 1)
+
+	w.mu.Lock()
+	w.subscribers[key] = append(w.subscribers[key], ch)
+	w.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			subs := w.subscribers[key]
+			for i, c := range subs {
+				if c == ch {
+					w.subscribers[key] = append(subs[:i], subs[i+1:]...)
+					break
+				}
+			}
+			if len(w.subscribers[key]) == 0 {
+				delete(w.subscribers, key)
+			}
+		})
+	}
+	return ch, cancel
+}
+
+// Run subscribes to keyspace notifications for status keys and dispatches
+// value changes to registered watchers until ctx is cancelled. It reconnects
+// with backoff on connection loss, signalling every waiting subscriber with
+// watcherDisconnected so they can fall back to polling while it does.
+func (w *KeyWatcher) Run(ctx context.Context) {
+	pattern := fmt.Sprintf("__keyspace@%d__:status:*", w.db)
+	backoff := watcherMinBackoff
+
+	for ctx.Err() == nil {
+		pubsub := w.rc.PSubscribe(ctx, pattern)
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("keywatcher: subscribe failed, retrying in %s: %v", backoff, err)
+			w.notifyDisconnected()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		backoff = watcherMinBackoff
+		w.consume(ctx, pubsub.Channel())
+		pubsub.Close()
+		w.notifyDisconnected()
+	}
+}
+
+func (w *KeyWatcher) consume(ctx context.Context, ch <-chan *redis.Message) {
+	prefix := fmt.Sprintf("__keyspace@%d__:", w.db)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			key := strings.TrimPrefix(msg.Channel, prefix)
+			val, err := w.rc.Get(ctx, key).Result()
+			if err != nil {
+				continue
+			}
+			w.dispatch(key, val)
+		}
+	}
+}
+
+func (w *KeyWatcher) dispatch(key, val string) {
+	w.mu.Lock()
+	subs := append([]chan string(nil), w.subscribers[key]...)
+	w.mu.Unlock()
+
+	for _, c := range subs {
+		select {
+		case c <- val:
+		default:
+		}
+	}
+}
+
+// notifyDisconnected wakes every active Watch caller so none of them block
+// for the full timeout waiting on a subscription that just went down.
+func (w *KeyWatcher) notifyDisconnected() {
+	w.mu.Lock()
+	all := make([]chan string, 0, len(w.subscribers))
+	for _, subs := range w.subscribers {
+		all = append(all, subs...)
+	}
+	w.mu.Unlock()
+
+	for _, c := range all {
+		select {
+		case c <- watcherDisconnected:
+		default:
+		}
+	}
+}
+
+func nextBackoff(cur time.Duration) time.Duration {
+	next := cur * 2
+	if next > watcherMaxBackoff {
+		return watcherMaxBackoff
+	}
+	return next
+}
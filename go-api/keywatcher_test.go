@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyWatcher_WatchDispatch(t *testing.T) {
+	w := NewKeyWatcher(nil, 0)
+
+	ch, cancel := w.Watch("status:abc")
+	defer cancel()
+
+	w.dispatch("status:abc", "completed")
+
+	select {
+	case v := <-ch:
+		assert.Equal(t, "completed", v)
+	case <-time.After(time.Second):
+		t.Fatal("expected dispatched value on watch channel")
+	}
+}
+
+func TestKeyWatcher_CancelStopsDelivery(t *testing.T) {
+	w := NewKeyWatcher(nil, 0)
+
+	ch, cancel := w.Watch("status:abc")
+	cancel()
+
+	// A cancelled watch should be removed from the subscriber map so a
+	// later dispatch has nothing to deliver to.
+	w.dispatch("status:abc", "completed")
+
+	select {
+	case v, ok := <-ch:
+		t.Fatalf("expected no delivery after cancel, got %q (ok=%v)", v, ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestKeyWatcher_NotifyDisconnectedSignalsAllWatchers(t *testing.T) {
+	w := NewKeyWatcher(nil, 0)
+
+	chA, cancelA := w.Watch("status:a")
+	defer cancelA()
+	chB, cancelB := w.Watch("status:b")
+	defer cancelB()
+
+	w.notifyDisconnected()
+
+	for _, ch := range []<-chan string{chA, chB} {
+		select {
+		case v := <-ch:
+			assert.Equal(t, watcherDisconnected, v)
+		case <-time.After(time.Second):
+			t.Fatal("expected disconnect signal")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// outboxStreamKey holds outbox entries awaiting a confirmed publish.
+	outboxStreamKey = "outbox"
+
+	outboxDefaultPollInterval = time.Second
+	outboxDefaultMaxAttempts  = 5
+	outboxDefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// outboxEnqueueScript atomically locks the idempotency key, records the
+// request as pending, and appends it to the outbox stream in one round
+// trip. Returns "duplicate" if the lock key already exists, "accepted"
+// otherwise. Collapsing these into a single script closes the gap a
+// separate EXISTS-then-SET pair would leave between the duplicate check and
+// the writes.
+var outboxEnqueueScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return "duplicate"
+end
+redis.call("SET", KEYS[1], "processing", "EX", ARGV[1])
+redis.call("SET", KEYS[2], "pending", "EX", ARGV[1])
+redis.call("XADD", KEYS[3], "*", "idempotency_key", ARGV[2], "uuid", ARGV[3], "payload", ARGV[4])
+return "accepted"
+`)
+
+// OutboxRelay drains outboxStreamKey and publishes each entry to the
+// configured broker, only removing the entry once the publish is confirmed.
+// This closes the gap where a crash between the Redis write and a
+// successful broker publish would otherwise leave a request's status
+// wedged at "pending" forever with nothing on the queue: the write and the
+// publish are now two independently retryable steps over durable state
+// instead of one all-or-nothing request.
+type OutboxRelay struct {
+	rdb       redis.UniversalClient
+	publisher message.Publisher
+
+	pollInterval time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+// NewOutboxRelay creates a relay that publishes to topicName via publisher.
+func NewOutboxRelay(rdb redis.UniversalClient, publisher message.Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		rdb:          rdb,
+		publisher:    publisher,
+		pollInterval: outboxDefaultPollInterval,
+		maxAttempts:  outboxDefaultMaxAttempts,
+		retryBackoff: outboxDefaultRetryBackoff,
+	}
+}
+
+// Run drains the outbox every pollInterval until ctx is cancelled. The first
+// pass doubles as startup recovery: it reads from the start of the stream,
+// so any entry left over from a previous process (because it crashed before
+// confirming the publish, or before trimming after one) is retried.
+func (o *OutboxRelay) Run(ctx context.Context) {
+	for {
+		if err := o.drainOnce(ctx); err != nil {
+			log.Printf("outbox: drain failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(o.pollInterval):
+		}
+	}
+}
+
+func (o *OutboxRelay) drainOnce(ctx context.Context) error {
+	entries, err := o.rdb.XRange(ctx, outboxStreamKey, "-", "+").Result()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		idempotencyKey, _ := entry.Values["idempotency_key"].(string)
+		uuid, _ := entry.Values["uuid"].(string)
+		payload, _ := entry.Values["payload"].(string)
+
+		msg := message.NewMessage(uuid, []byte(payload))
+		msg.Metadata.Set("idempotency_key", idempotencyKey)
+		if err := o.publishWithRetry(msg); err != nil {
+			log.Printf("outbox: giving up on entry %s after %d attempts: %v", entry.ID, o.maxAttempts, err)
+			continue
+		}
+
+		if err := o.rdb.XDel(ctx, outboxStreamKey, entry.ID).Err(); err != nil {
+			log.Printf("outbox: publish of entry %s confirmed but trim failed, it will be republished: %v", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+func (o *OutboxRelay) publishWithRetry(msg *message.Message) error {
+	var err error
+	for attempt := 0; attempt < o.maxAttempts; attempt++ {
+		if err = o.publisher.Publish(topicName, msg); err == nil {
+			return nil
+		}
+		time.Sleep(o.retryBackoff)
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingPublisher always errors, to exercise retry and give-up behavior
+// without a real broker.
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(topic string, msgs ...*message.Message) error {
+	return assert.AnError
+}
+
+func (failingPublisher) Close() error { return nil }
+
+func TestOutboxRelay_RecoversLeftoverEntries(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	logger := watermill.NewStdLogger(false, false)
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, logger)
+	messages, err := pubSub.Subscribe(context.Background(), topicName)
+	assert.NoError(t, err)
+
+	// Simulate an entry left behind by a process that crashed after the
+	// Redis write but before the relay ever ran.
+	ctx := context.Background()
+	res, err := outboxEnqueueScript.Run(ctx, rdb,
+		[]string{"leftover-key", "status:leftover-key", outboxStreamKey},
+		int(idempotencyKeyTTL.Seconds()), "leftover-key", watermill.NewUUID(), []byte(`{"amount":1}`),
+	).Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "accepted", res)
+
+	relay := NewOutboxRelay(rdb, pubSub)
+	assert.NoError(t, relay.drainOnce(ctx))
+
+	select {
+	case msg := <-messages:
+		assert.JSONEq(t, `{"amount":1}`, string(msg.Payload))
+		assert.Equal(t, "leftover-key", msg.Metadata.Get("idempotency_key"))
+		msg.Ack()
+	default:
+		t.Fatal("expected the leftover outbox entry to be recovered and published")
+	}
+
+	entries, err := rdb.XRange(ctx, outboxStreamKey, "-", "+").Result()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestOutboxRelay_KeepsEntryAfterPublishFailure(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	ctx := context.Background()
+	_, err = outboxEnqueueScript.Run(ctx, rdb,
+		[]string{"stuck-key", "status:stuck-key", outboxStreamKey},
+		int(idempotencyKeyTTL.Seconds()), "stuck-key", watermill.NewUUID(), []byte(`{}`),
+	).Text()
+	assert.NoError(t, err)
+
+	relay := NewOutboxRelay(rdb, failingPublisher{})
+	relay.maxAttempts = 1
+	relay.retryBackoff = 0
+	assert.NoError(t, relay.drainOnce(ctx))
+
+	entries, err := rdb.XRange(ctx, outboxStreamKey, "-", "+").Result()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
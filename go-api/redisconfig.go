@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig describes how to connect to Redis, covering the single-node,
+// Sentinel-backed, and Cluster topologies production deployments need.
+// Load it from TOML/env at startup and pass it to NewRedisClient.
+type RedisConfig struct {
+	// URL is a redis:// or rediss:// connection string for a single-node
+	// deployment. Ignored when Sentinel or Cluster is set.
+	URL string
+
+	// Cluster is a seed list of host:port addresses of cluster nodes. When
+	// set, NewRedisClient returns a ClusterClient; Sentinel and URL are
+	// ignored.
+	Cluster []string
+
+	// Sentinel is a seed list of host:port addresses of sentinel nodes.
+	// When set (and Cluster is not), NewRedisClient returns a
+	// sentinel-backed FailoverClient for SentinelMaster.
+	Sentinel         []string
+	SentinelMaster   string
+	SentinelPassword string
+
+	Password string
+	DB       int
+
+	// MaxIdle caps the number of idle connections kept in the pool.
+	MaxIdle int
+	// MaxActive caps the number of connections the pool can allocate in
+	// total, including ones currently in use.
+	MaxActive int
+
+	TLSEnabled            bool
+	TLSInsecureSkipVerify bool
+}
+
+// NewRedisClient builds the redis.UniversalClient cfg describes: a
+// ClusterClient when Cluster is set, a sentinel-backed FailoverClient when
+// Sentinel is set, or a single-node Client otherwise.
+func NewRedisClient(cfg RedisConfig) (redis.UniversalClient, error) {
+	var tlsConfig *tls.Config
+	if cfg.TLSEnabled {
+		tlsConfig = &tls.Config{InsecureSkipVerify: cfg.TLSInsecureSkipVerify}
+	}
+
+	switch {
+	case len(cfg.Cluster) > 0:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:          cfg.Cluster,
+			Password:       cfg.Password,
+			MaxIdleConns:   cfg.MaxIdle,
+			MaxActiveConns: cfg.MaxActive,
+			TLSConfig:      tlsConfig,
+		}), nil
+	case len(cfg.Sentinel) > 0:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.SentinelMaster,
+			SentinelAddrs:    cfg.Sentinel,
+			SentinelPassword: cfg.SentinelPassword,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			MaxIdleConns:     cfg.MaxIdle,
+			MaxActiveConns:   cfg.MaxActive,
+			TLSConfig:        tlsConfig,
+		}), nil
+	case cfg.URL != "":
+		opts, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("redis: parse url: %w", err)
+		}
+		if cfg.Password != "" {
+			opts.Password = cfg.Password
+		}
+		opts.MaxIdleConns = cfg.MaxIdle
+		opts.MaxActiveConns = cfg.MaxActive
+		opts.TLSConfig = tlsConfig
+		return redis.NewClient(opts), nil
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:           redisAddr,
+			Password:       cfg.Password,
+			DB:             cfg.DB,
+			MaxIdleConns:   cfg.MaxIdle,
+			MaxActiveConns: cfg.MaxActive,
+			TLSConfig:      tlsConfig,
+		}), nil
+	}
+}
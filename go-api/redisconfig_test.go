@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// miniredis doesn't implement Sentinel or Cluster protocol, so only the
+// single-node (URL) path can be exercised against a fake server here; the
+// Sentinel/Cluster branches are covered by inspection against a real Redis.
+func TestNewRedisClient_URL(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	rdb, err := NewRedisClient(RedisConfig{URL: "redis://" + mr.Addr()})
+	assert.NoError(t, err)
+	defer rdb.Close()
+
+	assert.NoError(t, rdb.Set(context.Background(), "k", "v", 0).Err())
+	val, err := mr.Get("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+}
+
+func TestNewRedisClient_URLSetsMaxIdleConns(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	rdb, err := NewRedisClient(RedisConfig{URL: "redis://" + mr.Addr(), MaxIdle: 5})
+	assert.NoError(t, err)
+	defer rdb.Close()
+
+	client, ok := rdb.(*redis.Client)
+	assert.True(t, ok)
+	assert.Equal(t, 5, client.Options().MaxIdleConns)
+	assert.Equal(t, 0, client.Options().MinIdleConns)
+}
+
+func TestNewRedisClient_Cluster(t *testing.T) {
+	rdb, err := NewRedisClient(RedisConfig{Cluster: []string{"localhost:7000", "localhost:7001"}})
+	assert.NoError(t, err)
+	assert.NotNil(t, rdb)
+}
+
+func TestNewRedisClient_Sentinel(t *testing.T) {
+	rdb, err := NewRedisClient(RedisConfig{Sentinel: []string{"localhost:26379"}, SentinelMaster: "mymaster"})
+	assert.NoError(t, err)
+	assert.NotNil(t, rdb)
+}